@@ -0,0 +1,95 @@
+// Package audioio 定义了一套与具体音频库解耦的输入/输出抽象。
+// 在这之前，播放器直接绑定 beep/speaker，录音器直接绑定 malgo，
+// 两条链路各用各的后端，既没法在没有声卡的环境里跑，也没法互相替换。
+// 这里把"打开一个音频输出/输入"抽成 Sink/Source 接口，
+// 具体实现（malgo、portaudio、file、null）各自在 init() 里注册到后端表，
+// 上层只通过后端名字（--backend）来选择。
+package audioio
+
+import "sort"
+
+// Format 描述一路 PCM 音频的采样率和声道数，不绑定任何具体后端
+type Format struct {
+	SampleRate int
+	Channels   int
+}
+
+// Sink 是一个可以写入交织浮点 PCM 样本的音频输出（扬声器、文件、空设备……）
+type Sink interface {
+	Write(samples []float32) (int, error)
+	Close() error
+}
+
+// Source 是一个可以读取交织浮点 PCM 样本的音频输入（麦克风、空设备……）
+type Source interface {
+	Read(samples []float32) (int, error)
+	Close() error
+}
+
+// Device 描述后端枚举出的一个输入或输出设备
+type Device struct {
+	ID   string // 传给 OpenSink/OpenSource 的标识，留空表示"使用默认设备"
+	Name string
+}
+
+// Backend 是一个可插拔的音频 I/O 实现
+type Backend interface {
+	Name() string
+	OpenSink(format Format, deviceID string) (Sink, error)
+	OpenSource(format Format, deviceID string) (Source, error)
+	PlaybackDevices() ([]Device, error)
+	CaptureDevices() ([]Device, error)
+}
+
+var backends = map[string]Backend{}
+
+// Register 把一个后端注册到全局表，由各后端文件的 init() 调用
+func Register(b Backend) { backends[b.Name()] = b }
+
+// Get 按名字查找已注册的后端；空字符串等价于 "malgo"（默认后端）
+func Get(name string) (Backend, error) {
+	if name == "" {
+		name = "malgo"
+	}
+	b, ok := backends[name]
+	if !ok {
+		return nil, &UnknownBackendError{Name: name, Known: Names()}
+	}
+	return b, nil
+}
+
+// Names 返回所有已注册后端的名字，malgo 固定排在最前面表示它是默认项
+func Names() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		if name != "malgo" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := backends["malgo"]; ok {
+		return append([]string{"malgo"}, names...)
+	}
+	return names
+}
+
+// UnknownBackendError 在 --backend 传入一个没有注册过的名字时返回
+type UnknownBackendError struct {
+	Name  string
+	Known []string
+}
+
+func (e *UnknownBackendError) Error() string {
+	return "audioio: 未知的后端 " + e.Name + "，可用: " + joinNames(e.Known)
+}
+
+func joinNames(names []string) string {
+	out := ""
+	for i, n := range names {
+		if i > 0 {
+			out += ", "
+		}
+		out += n
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package audioio
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+)
+
+// fileBackend 把输出直接渲染进一个 WAV 文件，不经过任何声卡，
+// 适合离线渲染或者需要确定性结果的场景；它不支持音频输入。
+// deviceID 在这个后端里被当作输出文件路径使用，留空则落盘到 render.wav。
+type fileBackend struct{}
+
+func init() { Register(&fileBackend{}) }
+
+func (fileBackend) Name() string { return "file" }
+
+func (fileBackend) PlaybackDevices() ([]Device, error) { return nil, nil }
+func (fileBackend) CaptureDevices() ([]Device, error)  { return nil, nil }
+
+func (fileBackend) OpenSink(format Format, deviceID string) (Sink, error) {
+	path := deviceID
+	if path == "" {
+		path = "render.wav"
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("audioio: 创建渲染文件失败: %w", err)
+	}
+
+	enc := wav.NewEncoder(f, format.SampleRate, 16, format.Channels, 1)
+	return &fileSink{file: f, enc: enc, format: format}, nil
+}
+
+func (fileBackend) OpenSource(format Format, deviceID string) (Source, error) {
+	return nil, fmt.Errorf("audioio: file 后端不支持音频输入")
+}
+
+type fileSink struct {
+	file   *os.File
+	enc    *wav.Encoder
+	format Format
+}
+
+func (s *fileSink) Write(samples []float32) (int, error) {
+	intData := make([]int, len(samples))
+	for i, v := range samples {
+		if v > 1 {
+			v = 1
+		}
+		if v < -1 {
+			v = -1
+		}
+		intData[i] = int(v * 32767)
+	}
+
+	buf := &audio.IntBuffer{
+		Format:         &audio.Format{SampleRate: s.format.SampleRate, NumChannels: s.format.Channels},
+		Data:           intData,
+		SourceBitDepth: 16,
+	}
+	if err := s.enc.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(samples), nil
+}
+
+func (s *fileSink) Close() error {
+	if err := s.enc.Close(); err != nil {
+		return err
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,262 @@
+package audioio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/gen2brain/malgo"
+)
+
+// malgoBackend 是默认后端：播放走一个独立的 malgo 播放设备，
+// 录音复用了原先 record.go 里那套设备初始化逻辑。
+type malgoBackend struct{}
+
+func init() { Register(&malgoBackend{}) }
+
+func (malgoBackend) Name() string { return "malgo" }
+
+func (malgoBackend) PlaybackDevices() ([]Device, error) { return malgoDevices(malgo.Playback) }
+func (malgoBackend) CaptureDevices() ([]Device, error)  { return malgoDevices(malgo.Capture) }
+
+func malgoDevices(kind malgo.DeviceType) ([]Device, error) {
+	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = ctx.Uninit()
+		ctx.Free()
+	}()
+
+	infos, err := ctx.Devices(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Device, len(infos))
+	for i, info := range infos {
+		out[i] = Device{ID: info.Name(), Name: info.Name()}
+	}
+	return out, nil
+}
+
+// findMalgoDevice 在枚举结果里按名字找一个设备，deviceID 为空时返回 nil（使用默认设备）
+func findMalgoDevice(ctx *malgo.AllocatedContext, kind malgo.DeviceType, deviceID string) (*malgo.DeviceInfo, error) {
+	if deviceID == "" {
+		return nil, nil
+	}
+	infos, err := ctx.Devices(kind)
+	if err != nil {
+		return nil, err
+	}
+	for i := range infos {
+		if infos[i].Name() == deviceID {
+			return &infos[i], nil
+		}
+	}
+	return nil, fmt.Errorf("audioio: 找不到设备 %q", deviceID)
+}
+
+type malgoSink struct {
+	ctx     *malgo.AllocatedContext
+	device  *malgo.Device
+	samples chan []float32
+	closed  chan struct{}
+
+	pending []float32 // 上一次回调剩下、还没写出去的样本；只在 Data 回调的音频线程里访问
+}
+
+func (malgoBackend) OpenSink(format Format, deviceID string) (Sink, error) {
+	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := findMalgoDevice(ctx, malgo.Playback, deviceID)
+	if err != nil {
+		_ = ctx.Uninit()
+		ctx.Free()
+		return nil, err
+	}
+
+	cfg := malgo.DefaultDeviceConfig(malgo.Playback)
+	cfg.Playback.Format = malgo.FormatS16
+	cfg.Playback.Channels = uint32(format.Channels)
+	cfg.SampleRate = uint32(format.SampleRate)
+	cfg.Alsa.NoMMap = 1
+	if info != nil {
+		cfg.Playback.DeviceID = info.ID.Pointer()
+	}
+
+	sink := &malgoSink{ctx: ctx, samples: make(chan []float32, 8), closed: make(chan struct{})}
+
+	callbacks := malgo.DeviceCallbacks{
+		Data: func(out, in []byte, frameCount uint32) {
+			sink.fillOutput(out)
+		},
+	}
+
+	device, err := malgo.InitDevice(ctx.Context, cfg, callbacks)
+	if err != nil {
+		_ = ctx.Uninit()
+		ctx.Free()
+		return nil, err
+	}
+	if err := device.Start(); err != nil {
+		device.Uninit()
+		_ = ctx.Uninit()
+		ctx.Free()
+		return nil, err
+	}
+
+	sink.device = device
+	return sink, nil
+}
+
+func (s *malgoSink) Write(samples []float32) (int, error) {
+	cp := make([]float32, len(samples))
+	copy(cp, samples)
+	select {
+	case s.samples <- cp:
+		return len(samples), nil
+	case <-s.closed:
+		return 0, fmt.Errorf("audioio: sink 已关闭")
+	}
+}
+
+func (s *malgoSink) Close() error {
+	close(s.closed)
+	s.device.Uninit()
+	_ = s.ctx.Uninit()
+	s.ctx.Free()
+	return nil
+}
+
+// fillOutput 精确填满 out 这一次回调要的 frameCount*channels 个样本：先花掉上一次
+// 回调剩下的 pending，再从 samples 队列里取新的一块；只有在队列也空了（真正欠载）
+// 时才把 out 剩下的部分清零，绝不会把 buf 里多出来的样本直接丢掉。
+func (s *malgoSink) fillOutput(out []byte) {
+	need := len(out) / 2
+	pos := 0
+	for pos < need {
+		if len(s.pending) == 0 {
+			select {
+			case buf := <-s.samples:
+				s.pending = buf
+			default:
+				zeroFillS16(out[pos*2:])
+				return
+			}
+		}
+		n := need - pos
+		if n > len(s.pending) {
+			n = len(s.pending)
+		}
+		writeFloat32AsS16(out[pos*2:pos*2+n*2], s.pending[:n])
+		s.pending = s.pending[n:]
+		pos += n
+	}
+}
+
+func writeFloat32AsS16(out []byte, samples []float32) {
+	for i, v := range samples {
+		if v > 1 {
+			v = 1
+		}
+		if v < -1 {
+			v = -1
+		}
+		s := int16(v * 32767)
+		out[i*2] = byte(s)
+		out[i*2+1] = byte(s >> 8)
+	}
+}
+
+func zeroFillS16(out []byte) {
+	for i := range out {
+		out[i] = 0
+	}
+}
+
+type malgoSource struct {
+	ctx    *malgo.AllocatedContext
+	device *malgo.Device
+	buf    chan []float32
+	closed chan struct{}
+}
+
+func (malgoBackend) OpenSource(format Format, deviceID string) (Source, error) {
+	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := findMalgoDevice(ctx, malgo.Capture, deviceID)
+	if err != nil {
+		_ = ctx.Uninit()
+		ctx.Free()
+		return nil, err
+	}
+
+	cfg := malgo.DefaultDeviceConfig(malgo.Capture)
+	cfg.Capture.Format = malgo.FormatS16
+	cfg.Capture.Channels = uint32(format.Channels)
+	cfg.SampleRate = uint32(format.SampleRate)
+	cfg.Alsa.NoMMap = 1
+	if info != nil {
+		cfg.Capture.DeviceID = info.ID.Pointer()
+	}
+
+	src := &malgoSource{buf: make(chan []float32, 64), closed: make(chan struct{})}
+
+	callbacks := malgo.DeviceCallbacks{
+		Data: func(out, in []byte, frameCount uint32) {
+			samples := make([]float32, len(in)/2)
+			for i := range samples {
+				s := int16(binary.LittleEndian.Uint16(in[i*2 : i*2+2]))
+				samples[i] = float32(s) / 32768
+			}
+			select {
+			case src.buf <- samples:
+			default:
+				// 消费跟不上就丢弃这一帧，避免卡住采集回调
+			}
+		},
+	}
+
+	device, err := malgo.InitDevice(ctx.Context, cfg, callbacks)
+	if err != nil {
+		_ = ctx.Uninit()
+		ctx.Free()
+		return nil, err
+	}
+	if err := device.Start(); err != nil {
+		device.Uninit()
+		_ = ctx.Uninit()
+		ctx.Free()
+		return nil, err
+	}
+
+	src.ctx = ctx
+	src.device = device
+	return src, nil
+}
+
+func (s *malgoSource) Read(samples []float32) (int, error) {
+	select {
+	case data := <-s.buf:
+		n := copy(samples, data)
+		return n, nil
+	case <-s.closed:
+		return 0, io.EOF
+	}
+}
+
+func (s *malgoSource) Close() error {
+	close(s.closed)
+	s.device.Uninit()
+	_ = s.ctx.Uninit()
+	s.ctx.Free()
+	return nil
+}
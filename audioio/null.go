@@ -0,0 +1,66 @@
+package audioio
+
+import (
+	"io"
+	"time"
+)
+
+// nullBackend 丢弃所有写入、只产生静音输入，不接触任何真实声卡，
+// 用于没有音频设备的 CI 环境里做确定性测试。
+type nullBackend struct{}
+
+func init() { Register(&nullBackend{}) }
+
+func (nullBackend) Name() string { return "null" }
+
+func (nullBackend) PlaybackDevices() ([]Device, error) { return nil, nil }
+func (nullBackend) CaptureDevices() ([]Device, error)  { return nil, nil }
+
+func (nullBackend) OpenSink(format Format, deviceID string) (Sink, error) {
+	return nullSink{}, nil
+}
+
+func (nullBackend) OpenSource(format Format, deviceID string) (Source, error) {
+	return &nullSource{format: format, closed: make(chan struct{})}, nil
+}
+
+type nullSink struct{}
+
+func (nullSink) Write(samples []float32) (int, error) { return len(samples), nil }
+func (nullSink) Close() error                         { return nil }
+
+type nullSource struct {
+	format Format
+	closed chan struct{}
+}
+
+// Read 始终返回静音，但按 format 的采样率把这次要填的帧数换算成墙钟时长去睡，
+// 避免调用方（比如 runRecord 的采集循环）在没有真实设备节流的情况下空转 CPU、
+// 无限制地往编码器里灌静音帧；Close 后立即以 io.EOF 结束阻塞中的 Read。
+func (s *nullSource) Read(samples []float32) (int, error) {
+	if s.format.Channels > 0 && s.format.SampleRate > 0 {
+		frames := len(samples) / s.format.Channels
+		wait := time.Duration(frames) * time.Second / time.Duration(s.format.SampleRate)
+		select {
+		case <-time.After(wait):
+		case <-s.closed:
+			return 0, io.EOF
+		}
+	}
+
+	select {
+	case <-s.closed:
+		return 0, io.EOF
+	default:
+	}
+
+	for i := range samples {
+		samples[i] = 0
+	}
+	return len(samples), nil
+}
+
+func (s *nullSource) Close() error {
+	close(s.closed)
+	return nil
+}
@@ -0,0 +1,233 @@
+package audioio
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// portaudioBackend 是 malgo 的替代方案：某些平台上 malgo 的底层驱动不稳定，
+// 这个后端直接走 PortAudio 的输入/输出流，未指定设备时使用默认设备。
+type portaudioBackend struct{}
+
+func init() { Register(&portaudioBackend{}) }
+
+func (portaudioBackend) Name() string { return "portaudio" }
+
+func (portaudioBackend) PlaybackDevices() ([]Device, error) { return portaudioDevices(false) }
+func (portaudioBackend) CaptureDevices() ([]Device, error)  { return portaudioDevices(true) }
+
+func portaudioDevices(input bool) ([]Device, error) {
+	if err := portaudio.Initialize(); err != nil {
+		return nil, err
+	}
+	defer portaudio.Terminate()
+
+	infos, err := portaudio.Devices()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Device
+	for _, d := range infos {
+		if input && d.MaxInputChannels > 0 || !input && d.MaxOutputChannels > 0 {
+			out = append(out, Device{ID: d.Name, Name: d.Name})
+		}
+	}
+	return out, nil
+}
+
+// findPortaudioDevice 在枚举结果里按名字找一个设备，deviceID 为空时返回 nil（使用默认设备）
+func findPortaudioDevice(input bool, deviceID string) (*portaudio.DeviceInfo, error) {
+	if deviceID == "" {
+		return nil, nil
+	}
+	infos, err := portaudio.Devices()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range infos {
+		ok := input && d.MaxInputChannels > 0 || !input && d.MaxOutputChannels > 0
+		if ok && d.Name == deviceID {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("audioio: 找不到设备 %q", deviceID)
+}
+
+type portaudioSink struct {
+	stream *portaudio.Stream
+	buf    chan []float32
+	closed chan struct{}
+
+	pending []float32 // 上一次回调剩下、还没写出去的样本；只在 cb 的音频线程里访问
+}
+
+func (portaudioBackend) OpenSink(format Format, deviceID string) (Sink, error) {
+	if err := portaudio.Initialize(); err != nil {
+		return nil, err
+	}
+
+	outputDevice, err := findPortaudioDevice(false, deviceID)
+	if err != nil {
+		portaudio.Terminate()
+		return nil, err
+	}
+	if outputDevice == nil {
+		outputDevice, err = portaudio.DefaultOutputDevice()
+		if err != nil {
+			portaudio.Terminate()
+			return nil, err
+		}
+	}
+
+	sink := &portaudioSink{buf: make(chan []float32, 8), closed: make(chan struct{})}
+	// cb 精确填满这一次回调要的 len(out) 个样本：先花掉上一次回调剩下的 pending，
+	// 再从 buf 队列里取新的一块；只有队列也空了（真正欠载）时才清零剩下的部分，
+	// 绝不会把一块里多出来的样本直接丢掉。
+	cb := func(out []float32) {
+		pos := 0
+		for pos < len(out) {
+			if len(sink.pending) == 0 {
+				select {
+				case data := <-sink.buf:
+					sink.pending = data
+				default:
+					for i := pos; i < len(out); i++ {
+						out[i] = 0
+					}
+					return
+				}
+			}
+			n := len(out) - pos
+			if n > len(sink.pending) {
+				n = len(sink.pending)
+			}
+			copy(out[pos:pos+n], sink.pending[:n])
+			sink.pending = sink.pending[n:]
+			pos += n
+		}
+	}
+
+	params := portaudio.StreamParameters{
+		Output: portaudio.StreamDeviceParameters{
+			Device:   outputDevice,
+			Channels: format.Channels,
+			Latency:  outputDevice.DefaultLowOutputLatency,
+		},
+		SampleRate:      float64(format.SampleRate),
+		FramesPerBuffer: portaudio.FramesPerBufferUnspecified,
+	}
+
+	stream, err := portaudio.OpenStream(params, cb)
+	if err != nil {
+		portaudio.Terminate()
+		return nil, err
+	}
+	if err := stream.Start(); err != nil {
+		stream.Close()
+		portaudio.Terminate()
+		return nil, err
+	}
+
+	sink.stream = stream
+	return sink, nil
+}
+
+func (s *portaudioSink) Write(samples []float32) (int, error) {
+	cp := make([]float32, len(samples))
+	copy(cp, samples)
+	select {
+	case s.buf <- cp:
+		return len(samples), nil
+	case <-s.closed:
+		return 0, fmt.Errorf("audioio: sink 已关闭")
+	}
+}
+
+func (s *portaudioSink) Close() error {
+	close(s.closed)
+	_ = s.stream.Stop()
+	_ = s.stream.Close()
+	portaudio.Terminate()
+	return nil
+}
+
+type portaudioSource struct {
+	stream *portaudio.Stream
+	buf    chan []float32
+	closed chan struct{}
+}
+
+func (portaudioBackend) OpenSource(format Format, deviceID string) (Source, error) {
+	if err := portaudio.Initialize(); err != nil {
+		return nil, err
+	}
+
+	inputDevice, err := findPortaudioDevice(true, deviceID)
+	if err != nil {
+		portaudio.Terminate()
+		return nil, err
+	}
+	if inputDevice == nil {
+		inputDevice, err = portaudio.DefaultInputDevice()
+		if err != nil {
+			portaudio.Terminate()
+			return nil, err
+		}
+	}
+
+	src := &portaudioSource{buf: make(chan []float32, 64), closed: make(chan struct{})}
+	cb := func(in []float32) {
+		cp := make([]float32, len(in))
+		copy(cp, in)
+		select {
+		case src.buf <- cp:
+		default:
+			// 消费跟不上就丢弃这一帧
+		}
+	}
+
+	params := portaudio.StreamParameters{
+		Input: portaudio.StreamDeviceParameters{
+			Device:   inputDevice,
+			Channels: format.Channels,
+			Latency:  inputDevice.DefaultLowInputLatency,
+		},
+		SampleRate:      float64(format.SampleRate),
+		FramesPerBuffer: portaudio.FramesPerBufferUnspecified,
+	}
+
+	stream, err := portaudio.OpenStream(params, cb)
+	if err != nil {
+		portaudio.Terminate()
+		return nil, err
+	}
+	if err := stream.Start(); err != nil {
+		stream.Close()
+		portaudio.Terminate()
+		return nil, err
+	}
+
+	src.stream = stream
+	return src, nil
+}
+
+func (s *portaudioSource) Read(samples []float32) (int, error) {
+	select {
+	case data := <-s.buf:
+		n := copy(samples, data)
+		return n, nil
+	case <-s.closed:
+		return 0, io.EOF
+	}
+}
+
+func (s *portaudioSource) Close() error {
+	close(s.closed)
+	_ = s.stream.Stop()
+	_ = s.stream.Close()
+	portaudio.Terminate()
+	return nil
+}
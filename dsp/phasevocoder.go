@@ -0,0 +1,309 @@
+// Package dsp 提供音频时域处理算法。目前只有一个相位声码器（phase vocoder），
+// 用于在不改变音高的前提下改变播放速度。
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+	"sync"
+
+	"github.com/faiface/beep"
+)
+
+const (
+	frameSize   = 1024                    // STFT 窗长，必须是 2 的幂
+	overlapRate = 4                       // 75% 重叠
+	analysisHop = frameSize / overlapRate // H_a = 256
+	bins        = frameSize/2 + 1
+)
+
+// hannWindow 生成长度为 n 的汉宁窗，分析和合成都复用同一个窗
+func hannWindow(n int) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(n-1)))
+	}
+	return w
+}
+
+var window = hannWindow(frameSize)
+
+// fft 原地基-2 Cooley-Tukey FFT；invert 为 true 时做逆变换（含 1/n 归一化）
+func fft(x []complex128, invert bool) {
+	n := len(x)
+	if n <= 1 {
+		return
+	}
+
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for length := 2; length <= n; length <<= 1 {
+		angle := 2 * math.Pi / float64(length)
+		if !invert {
+			angle = -angle
+		}
+		wlen := complex(math.Cos(angle), math.Sin(angle))
+		for i := 0; i < n; i += length {
+			w := complex(1, 0)
+			for j := 0; j < length/2; j++ {
+				u := x[i+j]
+				v := x[i+j+length/2] * w
+				x[i+j] = u + v
+				x[i+j+length/2] = u - v
+				w *= wlen
+			}
+		}
+	}
+
+	if invert {
+		for i := range x {
+			x[i] /= complex(float64(n), 0)
+		}
+	}
+}
+
+// wrapPhase 把相位差归约到 (-π, π]
+func wrapPhase(p float64) float64 {
+	for p > math.Pi {
+		p -= 2 * math.Pi
+	}
+	for p < -math.Pi {
+		p += 2 * math.Pi
+	}
+	return p
+}
+
+// channelState 保存单个声道的相位声码器状态
+type channelState struct {
+	inBuf     []float64 // 长度 frameSize 的滑动输入窗口
+	lastPhase []float64 // 上一个分析帧每个频点的相位
+	sumPhase  []float64 // 累积的合成相位（决定重建信号的瞬时频率）
+
+	outBuf    []float64 // overlap-add 输出缓冲，随播放动态增长
+	normBuf   []float64 // 和 outBuf 对齐的合成窗平方和，读出时用来归一化 OLA 增益
+	writePos  int       // 下一帧 OLA 写入的起始样本位置
+	readPos   int       // 下一个要交给播放器的样本位置
+	available int       // outBuf 中已经定型、可安全读取的样本数
+}
+
+func newChannelState() *channelState {
+	return &channelState{
+		inBuf:     make([]float64, frameSize),
+		lastPhase: make([]float64, bins),
+		sumPhase:  make([]float64, bins),
+		outBuf:    make([]float64, frameSize),
+		normBuf:   make([]float64, frameSize),
+	}
+}
+
+// olaNormEpsilon 以下的归一化系数视为"这个位置还没被任何合成窗覆盖"，直接当静音处理，
+// 避免除以一个接近 0 的数导致数值爆炸
+const olaNormEpsilon = 1e-6
+
+// readNormalized 取出 readPos 处的 OLA 样本并按窗平方和归一化，抵消双重汉宁窗
+// 叠加 75% 重叠带来的增益，再推进 readPos
+func (cs *channelState) readNormalized() float64 {
+	v := cs.outBuf[cs.readPos]
+	if norm := cs.normBuf[cs.readPos]; norm > olaNormEpsilon {
+		v /= norm
+	}
+	cs.readPos++
+	return v
+}
+
+// PhaseVocoder 包裹一个 beep.StreamSeekCloser，在 [0.5, 2.0] 倍速范围内
+// 变速不变调：对每个声道做 STFT（75% 重叠的 1024 点汉宁窗），按
+//
+//	φ_new[k] = φ_prev[k] + (ω_k + Δφ_wrapped/H_a) * H_s
+//
+// 传播相位，再用 OLA（重叠相加）把合成帧拼接回时域。
+type PhaseVocoder struct {
+	src beep.StreamSeekCloser
+
+	mu    sync.Mutex
+	speed float64
+	ch    [2]*channelState
+}
+
+// New 用默认 1.0x 速度包裹 src
+func New(src beep.StreamSeekCloser) *PhaseVocoder {
+	pv := &PhaseVocoder{src: src, speed: 1.0}
+	pv.ch[0] = newChannelState()
+	pv.ch[1] = newChannelState()
+	return pv
+}
+
+// SetSpeed 设置播放速度，范围裁剪到 [0.5, 2.0]
+func (pv *PhaseVocoder) SetSpeed(speed float64) {
+	pv.mu.Lock()
+	defer pv.mu.Unlock()
+	if speed < 0.5 {
+		speed = 0.5
+	}
+	if speed > 2.0 {
+		speed = 2.0
+	}
+	pv.speed = speed
+}
+
+// Speed 返回当前速度
+func (pv *PhaseVocoder) Speed() float64 {
+	pv.mu.Lock()
+	defer pv.mu.Unlock()
+	return pv.speed
+}
+
+// Stream 实现 beep.Streamer：不断产出合成帧直到填满 samples。
+// 1.0x 时直接透传底层流，跳过 STFT/ISTFT，避免无意义的 OLA 增益和开销；
+// 只有在变速声码器已经攒下的 available 样本耗尽之后才会切换进出直通模式，
+// 这样切换速度的瞬间不会丢掉还没读出的合成样本。
+func (pv *PhaseVocoder) Stream(samples [][2]float64) (n int, ok bool) {
+	for n < len(samples) {
+		if pv.ch[0].available == 0 {
+			pv.mu.Lock()
+			speed := pv.speed
+			pv.mu.Unlock()
+			if speed == 1.0 {
+				got, srcOK := pv.src.Stream(samples[n:])
+				n += got
+				if !srcOK {
+					break
+				}
+				continue
+			}
+			if !pv.synthesizeHop() {
+				break
+			}
+		}
+		for n < len(samples) && pv.ch[0].available > 0 {
+			samples[n][0] = pv.ch[0].readNormalized()
+			samples[n][1] = pv.ch[1].readNormalized()
+			pv.ch[0].available--
+			pv.ch[1].available--
+			n++
+		}
+	}
+	return n, n > 0
+}
+
+// synthesizeHop 从源流读取一个分析跳距 (H_a) 的新样本，对左右声道各做
+// 一次 STFT -> 相位传播 -> ISTFT，并把结果用 overlap-add 叠加进输出缓冲
+func (pv *PhaseVocoder) synthesizeHop() bool {
+	pv.mu.Lock()
+	speed := pv.speed
+	pv.mu.Unlock()
+
+	// H_s = H_a / speed：没有重采样器接在后面，声码器的时间拉伸就是最终的播放
+	// 速度，speed 越大输出跳距越小、单位时间内消耗的输入越多，时长才会变短。
+	synthHop := int(math.Round(float64(analysisHop) / speed))
+	if synthHop < 1 {
+		synthHop = 1
+	}
+
+	chunk := make([][2]float64, analysisHop)
+	got, _ := pv.src.Stream(chunk)
+	if got == 0 {
+		return false
+	}
+	for i := got; i < analysisHop; i++ {
+		chunk[i] = [2]float64{0, 0}
+	}
+
+	for c := 0; c < 2; c++ {
+		cs := pv.ch[c]
+
+		// synthesizeHop 只在 available 耗尽（readPos == writePos）时才会被调用，
+		// 这正是把已经读空的前缀滑出 outBuf 的时机：否则 outBuf 只增不减，
+		// 非 1.0x 倍速播放整首歌/一路没有结尾的 HTTP 电台会无限吃内存。
+		if cs.readPos > 0 {
+			copy(cs.outBuf, cs.outBuf[cs.readPos:])
+			copy(cs.normBuf, cs.normBuf[cs.readPos:])
+			cs.writePos -= cs.readPos
+			cs.readPos = 0
+		}
+
+		// 滑动输入窗口：丢弃最旧的 H_a 个样本，追加刚读到的 H_a 个
+		copy(cs.inBuf, cs.inBuf[analysisHop:])
+		for i := 0; i < analysisHop; i++ {
+			cs.inBuf[frameSize-analysisHop+i] = chunk[i][c]
+		}
+
+		spec := make([]complex128, frameSize)
+		for i, s := range cs.inBuf {
+			spec[i] = complex(s*window[i], 0)
+		}
+		fft(spec, false)
+
+		synth := make([]complex128, frameSize)
+		for k := 0; k < bins; k++ {
+			mag := cmplx.Abs(spec[k])
+			phase := cmplx.Phase(spec[k])
+
+			expected := 2 * math.Pi * float64(k) * float64(analysisHop) / float64(frameSize)
+			delta := wrapPhase(phase - cs.lastPhase[k] - expected)
+			cs.lastPhase[k] = phase
+
+			trueFreq := 2*math.Pi*float64(k)/float64(frameSize) + delta/float64(analysisHop)
+			cs.sumPhase[k] += trueFreq * float64(synthHop)
+
+			re := mag * math.Cos(cs.sumPhase[k])
+			im := mag * math.Sin(cs.sumPhase[k])
+			synth[k] = complex(re, im)
+			if k > 0 && k < frameSize/2 {
+				synth[frameSize-k] = complex(re, -im) // 共轭对称保证逆变换结果为实数
+			}
+		}
+		fft(synth, true)
+
+		needed := cs.writePos + frameSize
+		if needed > len(cs.outBuf) {
+			grownOut := make([]float64, needed)
+			copy(grownOut, cs.outBuf)
+			cs.outBuf = grownOut
+
+			grownNorm := make([]float64, needed)
+			copy(grownNorm, cs.normBuf)
+			cs.normBuf = grownNorm
+		}
+		for i := 0; i < frameSize; i++ {
+			// 合成帧又乘了一次窗（双重汉宁），normBuf 把同一份窗平方和累积起来，
+			// 读出时除掉它才能让变速前后的响度保持一致，否则 75% 重叠下增益会大于 1
+			cs.outBuf[cs.writePos+i] += real(synth[i]) * window[i]
+			cs.normBuf[cs.writePos+i] += window[i] * window[i]
+		}
+
+		cs.writePos += synthHop
+		cs.available += synthHop
+	}
+	return true
+}
+
+// Err 转发底层流的错误状态
+func (pv *PhaseVocoder) Err() error { return pv.src.Err() }
+
+// Len 转发底层流的样本总数（近似：不反映变速后的实际播放时长）
+func (pv *PhaseVocoder) Len() int { return pv.src.Len() }
+
+// Position 转发底层流已读取的样本位置
+func (pv *PhaseVocoder) Position() int { return pv.src.Position() }
+
+// Seek 定位底层流，并清空相位声码器内部状态，避免跳变后相位传播错乱
+func (pv *PhaseVocoder) Seek(p int) error {
+	pv.mu.Lock()
+	pv.ch[0] = newChannelState()
+	pv.ch[1] = newChannelState()
+	pv.mu.Unlock()
+	return pv.src.Seek(p)
+}
+
+// Close 转发关闭底层流
+func (pv *PhaseVocoder) Close() error { return pv.src.Close() }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/adfoke/gowave/audioio"
+	"github.com/faiface/beep"
+)
+
+// timePerBuf 是 engine 每次从 streamer 拉取的样本量对应的时长：
+// 太大会让暂停/切歌的反应变迟钝，太小会增加 goroutine 调度开销
+const timePerBuf = time.Second / 20
+
+// playbackEngine 用一个可插拔的 audioio.Sink 取代了 beep/speaker：
+// 它在自己的 goroutine 里不断从当前音轨的 Streamer 拉取样本并写进 sink，
+// 从而让播放器可以换用 malgo 以外的后端（portaudio/file/null），
+// 也让切歌不必重新初始化输出设备——只是把下一首的 streamer 喂给同一个 engine。
+type playbackEngine struct {
+	sink   audioio.Sink
+	format beep.Format
+
+	mu      sync.Mutex // 保护当前正在播放的 streamer，供 Position/Seek 等并发访问
+	jobs    chan trackJob
+	skip    chan struct{}
+	current io.Closer // 当前音轨可关闭的资源（通常是 dsp.PhaseVocoder），由 setCurrent 更新
+}
+
+type trackJob struct {
+	streamer beep.Streamer
+	done     chan bool
+}
+
+func newPlaybackEngine(sink audioio.Sink, format beep.Format) *playbackEngine {
+	e := &playbackEngine{
+		sink:   sink,
+		format: format,
+		jobs:   make(chan trackJob, 1),
+		skip:   make(chan struct{}, 1),
+	}
+	go e.run()
+	return e
+}
+
+// play 把一个音轨的 streamer 交给 engine 播放，返回的 channel 会在这首播完（或被 skip）时收到信号
+func (e *playbackEngine) play(streamer beep.Streamer) chan bool {
+	done := make(chan bool, 1)
+	e.jobs <- trackJob{streamer: streamer, done: done}
+	return done
+}
+
+// setCurrent 记录当前音轨可关闭的资源，供 close() 在程序退出时兜底关闭。
+// model.Update 每次都是值接收者，bubbletea 内部持有的是它返回的副本，
+// main.go 里 tea.NewProgram 最初拿到的那个 *model 不会跟着后续切歌更新——
+// 只有 engine 这个指针字段在所有副本里都指向同一个 playbackEngine，
+// 所以"当前在放哪首"只能交给 engine 自己记着，不能指望调用方的 model 指针。
+func (e *playbackEngine) setCurrent(c io.Closer) {
+	e.mu.Lock()
+	e.current = c
+	e.mu.Unlock()
+}
+
+// skipCurrent 让正在播放的音轨立即让位给下一个排队的 job，用于手动切歌（n/p）；
+// 自然播放完毕不需要调用它，engine 会自己检测到 Stream 返回 false
+func (e *playbackEngine) skipCurrent() {
+	select {
+	case e.skip <- struct{}{}:
+	default:
+	}
+}
+
+// Lock/Unlock 让调用方可以像过去用 speaker.Lock/Unlock 一样，
+// 安全地访问正在被 engine 读取的 streamer（Position/Seek 等）
+func (e *playbackEngine) Lock()   { e.mu.Lock() }
+func (e *playbackEngine) Unlock() { e.mu.Unlock() }
+
+func (e *playbackEngine) run() {
+	bufSize := e.format.SampleRate.N(timePerBuf)
+	buf := make([][2]float64, bufSize)
+
+	for job := range e.jobs {
+	streamLoop:
+		for {
+			select {
+			case <-e.skip:
+				break streamLoop
+			default:
+			}
+
+			e.mu.Lock()
+			n, ok := job.streamer.Stream(buf)
+			e.mu.Unlock()
+
+			if n > 0 {
+				e.write(buf[:n])
+			}
+			if !ok {
+				break streamLoop
+			}
+		}
+		job.done <- true
+	}
+}
+
+func (e *playbackEngine) write(buf [][2]float64) {
+	flat := make([]float32, len(buf)*e.format.NumChannels)
+	for i, s := range buf {
+		if e.format.NumChannels == 1 {
+			flat[i] = float32((s[0] + s[1]) / 2)
+			continue
+		}
+		flat[i*2] = float32(s[0])
+		flat[i*2+1] = float32(s[1])
+	}
+	// sink 满了会阻塞在这里，天然地把拉流速度限制在设备消费的速度上
+	_, _ = e.sink.Write(flat)
+}
+
+// close 停掉 engine，关闭当前音轨（如果有）和底层 sink；调用后不能再 play()
+func (e *playbackEngine) close() error {
+	close(e.jobs)
+
+	e.mu.Lock()
+	current := e.current
+	e.mu.Unlock()
+	if current != nil {
+		_ = current.Close()
+	}
+
+	return e.sink.Close()
+}
@@ -0,0 +1,119 @@
+package main
+
+import "math"
+
+// hannWindow 对输入样本应用汉宁窗，减少 FFT 频谱泄漏
+func hannWindow(samples []float64) []float64 {
+	n := len(samples)
+	windowed := make([]float64, n)
+	for i, s := range samples {
+		w := 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(n-1)))
+		windowed[i] = s * w
+	}
+	return windowed
+}
+
+// fft 对 x 做原地基-2 Cooley-Tukey FFT，要求 len(x) 为 2 的幂
+func fft(x []complex128) {
+	n := len(x)
+	if n <= 1 {
+		return
+	}
+
+	// 位逆序重排
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	// 蝶形运算，逐级合并
+	for length := 2; length <= n; length <<= 1 {
+		angle := -2 * math.Pi / float64(length)
+		wlen := complex(math.Cos(angle), math.Sin(angle))
+		for i := 0; i < n; i += length {
+			w := complex(1, 0)
+			for j := 0; j < length/2; j++ {
+				u := x[i+j]
+				v := x[i+j+length/2] * w
+				x[i+j] = u + v
+				x[i+j+length/2] = u - v
+				w *= wlen
+			}
+		}
+	}
+}
+
+// magnitudesDB 对时域样本做加窗 FFT，返回 [0, N/2) 个频点的 dB 幅值
+func magnitudesDB(samples []float64) []float64 {
+	n := len(samples)
+	windowed := hannWindow(samples)
+
+	buf := make([]complex128, n)
+	for i, s := range windowed {
+		buf[i] = complex(s, 0)
+	}
+	fft(buf)
+
+	out := make([]float64, n/2)
+	for k := 0; k < n/2; k++ {
+		mag := math.Hypot(real(buf[k]), imag(buf[k]))
+		// 归一化后转 dB，加一个极小值避免 log(0)
+		db := 20 * math.Log10(mag/float64(n)+1e-12)
+		out[k] = db
+	}
+	return out
+}
+
+// logBin 把线性的 FFT 频点按对数方式分桶，聚合成 barCount 根柱子
+// 每个柱子取桶内的峰值 dB，符合人耳对数感知的频谱展示习惯
+func logBin(magsDB []float64, barCount int) []float64 {
+	n := len(magsDB)
+	bars := make([]float64, barCount)
+
+	// 跳过直流分量 (k=0)，从 1 开始做对数映射
+	logMin := math.Log2(1)
+	logMax := math.Log2(float64(n))
+	for b := 0; b < barCount; b++ {
+		loFrac := float64(b) / float64(barCount)
+		hiFrac := float64(b+1) / float64(barCount)
+		lo := int(math.Exp2(logMin+loFrac*(logMax-logMin))) + 1
+		hi := int(math.Exp2(logMin + hiFrac*(logMax-logMin)))
+		if hi >= n {
+			hi = n - 1
+		}
+		if lo > hi {
+			lo = hi
+		}
+
+		peak := -math.MaxFloat64
+		for k := lo; k <= hi && k < n; k++ {
+			if magsDB[k] > peak {
+				peak = magsDB[k]
+			}
+		}
+		bars[b] = peak
+	}
+	return bars
+}
+
+// rmsAndPeak 计算时域样本的 RMS 和峰值幅度 (线性 0~1)
+func rmsAndPeak(samples []float64) (rms, peak float64) {
+	var sumSquares float64
+	for _, s := range samples {
+		abs := math.Abs(s)
+		if abs > peak {
+			peak = abs
+		}
+		sumSquares += s * s
+	}
+	if len(samples) > 0 {
+		rms = math.Sqrt(sumSquares / float64(len(samples)))
+	}
+	return rms, peak
+}
@@ -0,0 +1,170 @@
+// Package format 提供一个按魔数/扩展名嗅探的解码器注册表，
+// 让 play 命令可以接受 WAV/MP3/FLAC/OGG Vorbis 文件，以及 HTTP(S) 流。
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/flac"
+	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/vorbis"
+	"github.com/faiface/beep/wav"
+)
+
+// Source 是解码器需要的最小输入能力：可读、可定位、可关闭。
+// 本地文件 (*os.File) 天然满足；HTTP 来源由 httpSource 适配。
+type Source interface {
+	io.Reader
+	io.Seeker
+	io.Closer
+}
+
+// Decoded 聚合解码结果，以及 TUI 标题栏展示用的元数据
+type Decoded struct {
+	Streamer beep.StreamSeekCloser
+	Format   beep.Format
+	Name     string // 格式名称，如 "MP3"
+	Bitrate  int    // 解码后 PCM 的码率 (kbps)，见 bitrateKbps
+}
+
+type decodeFunc func(Source) (*Decoded, error)
+
+type registration struct {
+	name   string
+	magic  []byte // 文件头魔数前缀，为空表示只能靠扩展名识别
+	exts   []string
+	decode decodeFunc
+}
+
+var registry []registration
+
+func register(r registration) { registry = append(registry, r) }
+
+func init() {
+	register(registration{name: "WAV", magic: []byte("RIFF"), exts: []string{".wav"}, decode: decodeWAV})
+	register(registration{name: "FLAC", magic: []byte("fLaC"), exts: []string{".flac"}, decode: decodeFLAC})
+	register(registration{name: "OGG Vorbis", magic: []byte("OggS"), exts: []string{".ogg", ".oga"}, decode: decodeVorbis})
+	register(registration{name: "MP3", magic: []byte("ID3"), exts: []string{".mp3"}, decode: decodeMP3})
+}
+
+// Open 打开一个本地路径或 http(s):// URL，嗅探格式后解码并返回流
+func Open(pathOrURL string) (*Decoded, error) {
+	src, ext, err := openSource(pathOrURL)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 12)
+	n, _ := io.ReadFull(src, header)
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		src.Close()
+		return nil, fmt.Errorf("定位文件头失败: %w", err)
+	}
+
+	reg := match(header[:n], ext)
+	if reg == nil {
+		src.Close()
+		return nil, fmt.Errorf("无法识别的音频格式: %s", pathOrURL)
+	}
+
+	d, err := reg.decode(src)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func openSource(pathOrURL string) (src Source, ext string, err error) {
+	if strings.HasPrefix(pathOrURL, "http://") || strings.HasPrefix(pathOrURL, "https://") {
+		hs, err := newHTTPSource(pathOrURL)
+		if err != nil {
+			return nil, "", fmt.Errorf("打开 HTTP 音频源失败: %w", err)
+		}
+		return hs, extOf(pathOrURL), nil
+	}
+
+	f, err := os.Open(pathOrURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	return f, extOf(pathOrURL), nil
+}
+
+func extOf(pathOrURL string) string {
+	if i := strings.LastIndex(pathOrURL, "."); i >= 0 {
+		return strings.ToLower(pathOrURL[i:])
+	}
+	return ""
+}
+
+// match 优先按魔数匹配；mp3 常常没有 ID3 头（裸帧同步开头），
+// 因此还要识别帧同步字节，最后才按扩展名兜底
+func match(header []byte, ext string) *registration {
+	for i := range registry {
+		r := &registry[i]
+		if len(r.magic) > 0 && bytes.HasPrefix(header, r.magic) {
+			return r
+		}
+	}
+
+	if len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0 {
+		for i := range registry {
+			if registry[i].name == "MP3" {
+				return &registry[i]
+			}
+		}
+	}
+
+	for i := range registry {
+		for _, e := range registry[i].exts {
+			if e == ext {
+				return &registry[i]
+			}
+		}
+	}
+	return nil
+}
+
+func decodeWAV(src Source) (*Decoded, error) {
+	s, f, err := wav.Decode(src)
+	if err != nil {
+		return nil, fmt.Errorf("解码 WAV 失败: %w", err)
+	}
+	return &Decoded{Streamer: s, Format: f, Name: "WAV", Bitrate: bitrateKbps(f)}, nil
+}
+
+func decodeMP3(src Source) (*Decoded, error) {
+	s, f, err := mp3.Decode(src)
+	if err != nil {
+		return nil, fmt.Errorf("解码 MP3 失败: %w", err)
+	}
+	return &Decoded{Streamer: s, Format: f, Name: "MP3", Bitrate: bitrateKbps(f)}, nil
+}
+
+func decodeFLAC(src Source) (*Decoded, error) {
+	s, f, err := flac.Decode(src)
+	if err != nil {
+		return nil, fmt.Errorf("解码 FLAC 失败: %w", err)
+	}
+	return &Decoded{Streamer: s, Format: f, Name: "FLAC", Bitrate: bitrateKbps(f)}, nil
+}
+
+func decodeVorbis(src Source) (*Decoded, error) {
+	s, f, err := vorbis.Decode(src)
+	if err != nil {
+		return nil, fmt.Errorf("解码 OGG Vorbis 失败: %w", err)
+	}
+	return &Decoded{Streamer: s, Format: f, Name: "OGG Vorbis", Bitrate: bitrateKbps(f)}, nil
+}
+
+// bitrateKbps 用采样率 * 声道数 * 采样精度算出解码后 PCM 的码率，仅用于 UI 展示；
+// 对有损格式（MP3/Vorbis）它不是文件本身的编码码率
+func bitrateKbps(f beep.Format) int {
+	return int(f.SampleRate) * f.NumChannels * f.Precision * 8 / 1000
+}
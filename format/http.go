@@ -0,0 +1,148 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// httpSource 把一个 HTTP(S) URL 适配成 Source。如果服务端对 Range 探测请求
+// 回了 206 并在 Content-Range 里给出总长度，Seek 之后的 Read 会按需发起新的
+// Range 请求；否则退化为把整个资源下载进内存缓冲区。
+type httpSource struct {
+	url    string
+	client *http.Client
+
+	ranged bool
+	size   int64
+	pos    int64
+	body   io.ReadCloser // 当前 Range 请求的响应体 (ranged 模式)
+
+	full *bytes.Reader // 回退模式下的完整内存缓冲
+}
+
+func newHTTPSource(url string) (*httpSource, error) {
+	client := http.DefaultClient
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只认状态码：206 就说明服务端确实按 Range 回的，哪怕它没在响应里加
+	// Accept-Ranges（RFC 允许 206 不带这个头，不少服务器也确实不带）
+	if resp.StatusCode == http.StatusPartialContent {
+		resp.Body.Close()
+		size := int64(-1)
+		if cr := resp.Header.Get("Content-Range"); cr != "" {
+			var total int64
+			if _, err := fmt.Sscanf(cr, "bytes 0-0/%d", &total); err == nil {
+				size = total
+			}
+		}
+		// Content-Range 缺失或解析不出总长度时，ranged 模式下 Seek(0, io.SeekEnd)
+		// （wav.Decode 会这么调）就只能瞎猜；这种总长度未知的情况不值得信任 206，
+		// 退回到下面的全量下载模式，让 bytes.Reader 自己算出准确长度。
+		if size > 0 {
+			return &httpSource{url: url, client: client, ranged: true, size: size}, nil
+		}
+	} else {
+		resp.Body.Close()
+	}
+
+	// 服务端不支持 Range，或支持但报不出总长度；探测响应已经关闭（206 时
+	// 它只有请求的 1 字节），不拿它当完整文件，换一个不带 Range 头的
+	// 全新请求来取完整内容
+	fullReq, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	fullResp, err := client.Do(fullReq)
+	if err != nil {
+		return nil, err
+	}
+	defer fullResp.Body.Close()
+	data, err := io.ReadAll(fullResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("下载音频失败: %w", err)
+	}
+	return &httpSource{url: url, client: client, full: bytes.NewReader(data)}, nil
+}
+
+func (s *httpSource) Read(p []byte) (int, error) {
+	if s.full != nil {
+		return s.full.Read(p)
+	}
+
+	if s.body == nil {
+		if err := s.openRangeFrom(s.pos); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := s.body.Read(p)
+	s.pos += int64(n)
+	if err == io.EOF {
+		s.body.Close()
+		s.body = nil
+	}
+	return n, err
+}
+
+func (s *httpSource) Seek(offset int64, whence int) (int64, error) {
+	if s.full != nil {
+		return s.full.Seek(offset, whence)
+	}
+
+	var newPos int64
+	switch whence {
+	case io.SeekStart:
+		newPos = offset
+	case io.SeekCurrent:
+		newPos = s.pos + offset
+	case io.SeekEnd:
+		newPos = s.size + offset
+	default:
+		return 0, fmt.Errorf("format: 不支持的 seek whence %d", whence)
+	}
+
+	if s.body != nil {
+		s.body.Close()
+		s.body = nil
+	}
+	s.pos = newPos
+	return newPos, nil
+}
+
+func (s *httpSource) Close() error {
+	if s.body != nil {
+		return s.body.Close()
+	}
+	return nil
+}
+
+func (s *httpSource) openRangeFrom(offset int64) error {
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return fmt.Errorf("format: 服务端返回意外状态码 %d", resp.StatusCode)
+	}
+	s.body = resp.Body
+	return nil
+}
@@ -1,36 +1,58 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"math"
 	"os"
 	"time"
 
+	"github.com/adfoke/gowave/audioio"
+	"github.com/adfoke/gowave/dsp"
+	"github.com/adfoke/gowave/format"
+	"github.com/adfoke/gowave/playlist"
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/faiface/beep"
-	"github.com/faiface/beep/speaker"
-	"github.com/faiface/beep/wav"
 )
 
 // --- 1. 数据模型定义 ---
 
 // audioState 存放音频底层的对象
 type audioState struct {
-	streamer beep.StreamSeekCloser // 音频流，用于读取数据
-	format   beep.Format           // 音频格式信息（采样率等）
-	ctrl     *beep.Ctrl            // 控制器，用于实现暂停功能
-	duration time.Duration         // 总时长
-	done     chan bool             // 播放完成的信号通道
+	streamer   beep.StreamSeekCloser // 音频流，用于读取数据（经过变速声码器包装）
+	pv         *dsp.PhaseVocoder     // 变速不变调声码器，控制播放速度
+	format     beep.Format           // 音频格式信息（采样率等）
+	ctrl       *beep.Ctrl            // 控制器，用于实现暂停功能
+	duration   time.Duration         // 总时长
+	done       chan bool             // 播放完成的信号通道
+	formatName string                // 解码器识别出的格式名，如 "MP3"
+	bitrate    int                   // 解码后 PCM 的码率 (kbps)
+}
+
+// playerOptions 是 `gowave play` 里跟音频后端相关的命令行选项
+type playerOptions struct {
+	backend      string // audioio 后端名字："malgo"（默认）、"portaudio"、"file"、"null"
+	outputDevice string // 留空表示使用该后端的默认输出设备
 }
 
 // model 是 Bubble Tea 的核心状态存储
 type model struct {
-	audio    *audioState    // 音频状态
-	progress progress.Model // 进度条组件
-	filename string         // 文件名
-	playing  bool           // UI 显示的播放状态
-	pct      float64        // 当前进度百分比 (0.0 - 1.0)
-	err      error          // 错误信息
+	audio    *audioState     // 音频状态
+	engine   *playbackEngine // 拉流并写入所选 audioio 后端的播放引擎，贯穿整个会话
+	queue    *playlist.Queue // 播放队列：展开后的音轨列表及导航状态
+	progress progress.Model  // 进度条组件
+	filename string          // 当前音轨文件名
+	playing  bool            // UI 显示的播放状态
+	pct      float64         // 当前进度百分比 (0.0 - 1.0)
+	err      error           // 错误信息
+
+	ring     *sampleRing   // 镜像最近播放样本的环形缓冲区，供频谱/VU 表使用
+	spectrum spectrumState // 每个 tick 派生出的频谱柱和电平数据
+
+	speed float64 // 播放速度 (0.5x~2.0x)，切歌后沿用
+	loopA int     // A/B 循环的 A 点（采样位置），-1 表示未设置
+	loopB int     // A/B 循环的 B 点（采样位置），-1 表示未设置
 }
 
 // --- 2. 消息定义 ---
@@ -47,63 +69,149 @@ func tickCmd() tea.Cmd {
 
 // --- 3. 初始化逻辑 ---
 
-func initialModel(filename string) (*model, error) {
-	f, err := os.Open(filename)
+func initialModel(queue *playlist.Queue, opts playerOptions) (*model, error) {
+	ring := newSampleRing(fftSize * 4)
+
+	// 按魔数/扩展名嗅探并解码：支持本地 WAV/MP3/FLAC/OGG Vorbis，
+	// 以及 http(s):// 流式来源
+	decoded, err := format.Open(queue.Current())
 	if err != nil {
-		return nil, fmt.Errorf("打开文件失败: %w", err)
+		return nil, err
 	}
 
-	// 使用 Beep 解码 wav 文件
-	streamer, format, err := wav.Decode(f)
+	backend, err := audioio.Get(opts.backend)
 	if err != nil {
-		f.Close()
-		return nil, fmt.Errorf("解码 WAV 失败: %w", err)
+		decoded.Streamer.Close()
+		return nil, err
 	}
 
-	// 初始化扬声器 (只需初始化一次)
-	// SampleRate.N(time.Second/10) 决定了缓冲区大小，影响延迟稳定性
-	err = speaker.Init(format.SampleRate, format.SampleRate.N(time.Second/10))
+	// 打开输出 sink（只需打开一次，后续切歌复用同一个 engine/sink）
+	sink, err := backend.OpenSink(audioio.Format{
+		SampleRate: int(decoded.Format.SampleRate),
+		Channels:   decoded.Format.NumChannels,
+	}, opts.outputDevice)
 	if err != nil {
-		streamer.Close()
-		return nil, fmt.Errorf("初始化扬声器失败: %w", err)
+		decoded.Streamer.Close()
+		return nil, fmt.Errorf("打开音频输出失败 (后端 %s): %w", backend.Name(), err)
 	}
+	engine := newPlaybackEngine(sink, decoded.Format)
 
-	// 创建一个可暂停的控制器 (Ctrl)
-	ctrl := &beep.Ctrl{Streamer: streamer, Paused: false}
-
-	// 播放音频
-	// speaker.Play 是异步的，不会阻塞主线程
-	done := make(chan bool)
-	speaker.Play(beep.Seq(ctrl, beep.Callback(func() {
-		// 播放序列结束后，向 done 通道发送信号
-		done <- true
-	})))
-
-	// 计算音频总时长
-	duration := format.SampleRate.D(streamer.Len())
-
-	// 初始化进度条组件
 	prog := progress.New(progress.WithDefaultGradient())
 
 	return &model{
-		audio: &audioState{
-			streamer: streamer,
-			format:   format,
-			ctrl:     ctrl,
-			duration: duration,
-			done:     done,
-		},
+		audio:    playTrack(decoded, ring, 1.0, engine),
+		engine:   engine,
+		queue:    queue,
 		progress: prog,
-		filename: filename,
+		filename: queue.Current(),
 		playing:  true,
 		pct:      0.0,
+		ring:     ring,
+		speed:    1.0,
+		loopA:    -1,
+		loopB:    -1,
 	}, nil
 }
 
-// Close 释放资源
+// playTrack 用已解码的音轨构造一个新的 audioState，并把它交给 engine 播放。
+// 解码出的流先包一层相位声码器用于变速不变调，再包一层 Ctrl 用于暂停。
+// engine 在整个播放会话里只创建一次，切歌时只是把新音轨的 streamer 喂给它，
+// 不需要重新打开输出设备。
+func playTrack(decoded *format.Decoded, ring *sampleRing, speed float64, engine *playbackEngine) *audioState {
+	pv := dsp.New(decoded.Streamer)
+	pv.SetSpeed(speed)
+
+	ctrl := &beep.Ctrl{Streamer: pv, Paused: false}
+
+	// tap 把每次播放的缓冲区镜像进 ring，供 UI 侧计算频谱和 VU 表，
+	// 不影响正常的播放链路
+	tapped := newTapStreamer(ctrl, ring)
+
+	done := engine.play(tapped)
+	engine.setCurrent(pv)
+
+	return &audioState{
+		streamer:   pv,
+		pv:         pv,
+		format:     decoded.Format,
+		ctrl:       ctrl,
+		duration:   decoded.Format.SampleRate.D(decoded.Streamer.Len()),
+		done:       done,
+		formatName: decoded.Name,
+		bitrate:    decoded.Bitrate,
+	}
+}
+
+// advanceTo 切换到队列中的另一首音轨：复用同一个 engine/sink，
+// 解码新文件并把它交给 engine 继续播放，从而实现不重启播放进程的连续切歌。
+// 当前的播放速度会原样带到下一首；A/B 循环点是按音轨设置的，随之重置。
+func (m *model) advanceTo(path string) {
+	old := m.audio
+
+	decoded, err := format.Open(path)
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	m.audio = playTrack(decoded, m.ring, m.speed, m.engine)
+	m.filename = path
+	m.pct = 0
+	m.err = nil
+	m.loopA = -1
+	m.loopB = -1
+
+	if old != nil {
+		// engine 的 run() goroutine 在同一把锁下读 old.streamer；jobs 是带缓冲的
+		// channel，play() 一入队就返回，不代表 run() 已经退出旧音轨的 streamLoop。
+		// 不加锁直接 Close 会和还在跑的 Stream() 调用撞上，对解码器形成 use-after-close。
+		m.engine.Lock()
+		old.streamer.Close()
+		m.engine.Unlock()
+	}
+}
+
+// skipTo 是手动切歌（n/p）用的 advanceTo：先让 engine 立即让出当前音轨，
+// 不必等它自然播完，再切到目标音轨
+func (m *model) skipTo(path string) {
+	m.engine.skipCurrent()
+	m.advanceTo(path)
+}
+
+// seekBy 把播放位置移动 d（可正可负），越界会裁剪到 [0, Len())
+func (m *model) seekBy(d time.Duration) {
+	m.engine.Lock()
+	defer m.engine.Unlock()
+
+	pos := m.audio.streamer.Position() + m.audio.format.SampleRate.N(d)
+	if pos < 0 {
+		pos = 0
+	}
+	if length := m.audio.streamer.Len(); pos >= length {
+		pos = length - 1
+	}
+	if err := m.audio.streamer.Seek(pos); err != nil {
+		m.err = err
+	}
+}
+
+func clampSpeed(speed float64) float64 {
+	if speed < 0.5 {
+		return 0.5
+	}
+	if speed > 2.0 {
+		return 2.0
+	}
+	return math.Round(speed*10) / 10
+}
+
+// Close 释放资源。不能通过 m.audio.streamer 关闭——Update 是值接收者，
+// bubbletea 内部持有的模型在切歌后会和 runPlayer 手里这个 *model 分道扬镳，
+// m.audio 在这里看到的永远是最初那首歌；真正在放的那首由 engine 自己记着，
+// 一并在 engine.close() 里关闭，避免关错歌/重复关闭。
 func (m *model) Close() {
-	if m.audio != nil && m.audio.streamer != nil {
-		m.audio.streamer.Close()
+	if m.engine != nil {
+		m.engine.close()
 	}
 }
 
@@ -128,13 +236,74 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.audio.ctrl.Paused = !m.audio.ctrl.Paused
 			m.playing = !m.audio.ctrl.Paused
 			return m, nil
+
+		case "n": // 手动切到下一首
+			if next, ok := m.queue.Next(); ok {
+				m.skipTo(next)
+			}
+			return m, nil
+
+		case "p": // 手动切到上一首
+			m.skipTo(m.queue.Prev())
+			return m, nil
+
+		case "s": // 切换乱序播放
+			m.queue.ToggleShuffle()
+			return m, nil
+
+		case "r": // 循环模式: off -> single -> all -> off
+			m.queue.CycleRepeat()
+			return m, nil
+
+		case "left": // 快退 5s
+			m.seekBy(-5 * time.Second)
+			return m, nil
+
+		case "right": // 快进 5s
+			m.seekBy(5 * time.Second)
+			return m, nil
+
+		case "shift+left": // 快退 30s
+			m.seekBy(-30 * time.Second)
+			return m, nil
+
+		case "shift+right": // 快进 30s
+			m.seekBy(30 * time.Second)
+			return m, nil
+
+		case "+", "=": // 加速 0.1x，上限 2.0x
+			m.speed = clampSpeed(m.speed + 0.1)
+			m.audio.pv.SetSpeed(m.speed)
+			return m, nil
+
+		case "-": // 减速 0.1x，下限 0.5x
+			m.speed = clampSpeed(m.speed - 0.1)
+			m.audio.pv.SetSpeed(m.speed)
+			return m, nil
+
+		case "[": // 设置 A/B 循环的 A 点为当前播放位置
+			m.engine.Lock()
+			m.loopA = m.audio.streamer.Position()
+			m.engine.Unlock()
+			return m, nil
+
+		case "]": // 设置 A/B 循环的 B 点为当前播放位置
+			m.engine.Lock()
+			m.loopB = m.audio.streamer.Position()
+			m.engine.Unlock()
+			return m, nil
 		}
 
 	// 定时器消息
 	case tickMsg:
-		// 1. 检查音频是否播放完毕
+		// 1. 检查当前音轨是否自然播放完毕
 		select {
 		case <-m.audio.done:
+			if next, ok := m.queue.Next(); ok {
+				// 推进到下一首，复用同一个 engine/sink，不退出 TUI
+				m.advanceTo(next)
+				return m, tickCmd()
+			}
 			return m, tea.Quit
 		default:
 		}
@@ -145,10 +314,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// 3. 获取当前播放位置
-		// 注意：Beep 在另一个 goroutine 运行，访问位置需要加锁
-		speaker.Lock()
+		// 注意：engine 在另一个 goroutine 里读取 streamer，访问位置需要加锁
+		m.engine.Lock()
 		position := m.audio.streamer.Position()
-		speaker.Unlock()
+		// A/B 循环：两个点都设置好之后，一旦越过 B 就跳回 A
+		if m.loopA >= 0 && m.loopB > m.loopA && position >= m.loopB {
+			if err := m.audio.streamer.Seek(m.loopA); err == nil {
+				position = m.loopA
+			}
+		}
+		m.engine.Unlock()
 
 		// 4. 计算百分比
 		length := m.audio.streamer.Len()
@@ -156,7 +331,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.pct = float64(position) / float64(length)
 		}
 
-		// 5. 更新进度条组件，并请求下一帧
+		// 5. 派生本帧的频谱柱和 VU 电平
+		m.spectrum.update(m.ring)
+
+		// 6. 更新进度条组件，并请求下一帧
 		cmd := m.progress.SetPercent(m.pct)
 		return m, tea.Batch(cmd, tickCmd())
 
@@ -197,10 +375,32 @@ func (m model) View() string {
 	// 界面布局
 	s := "\n"
 	s += fmt.Sprintf("🎵 File: \033[1m%s\033[0m\n", m.filename) // 粗体显示文件名
+	s += fmt.Sprintf("   %s  %dHz  ~%dkbps\n", m.audio.formatName, int(m.audio.format.SampleRate), m.audio.bitrate)
 	s += fmt.Sprintf("   %s\n\n", status)
 	s += "   " + m.progress.View() + "\n\n"
 	s += fmt.Sprintf("   ⏱  %v / %v\n\n", currentPos, totalDur)
-	s += "   [Space] Play/Pause  [q] Quit\n\n"
+
+	// 频谱柱和 VU 表
+	s += "   " + renderSpectrum(m.spectrum.bars) + "\n\n"
+	s += "   " + renderMeter("RMS ", linearToDBFrac(m.spectrum.rms), 0, 40) + "\n"
+	s += "   " + renderMeter("Peak", linearToDBFrac(m.spectrum.peak), linearToDBFrac(m.spectrum.peakHold), 40) + "\n\n"
+
+	// 队列状态和接下来的曲目
+	s += fmt.Sprintf("   Queue: shuffle=%v repeat=%s  Speed: %.1fx", m.queue.Shuffle(), m.queue.Repeat(), m.speed)
+	if m.loopA >= 0 {
+		s += fmt.Sprintf("  Loop A=%v", m.audio.format.SampleRate.D(m.loopA).Round(time.Second))
+	}
+	if m.loopB >= 0 {
+		s += fmt.Sprintf(" B=%v", m.audio.format.SampleRate.D(m.loopB).Round(time.Second))
+	}
+	s += "\n"
+	for i, t := range m.queue.Upcoming(3) {
+		s += fmt.Sprintf("     %d. %s\n", i+1, t)
+	}
+	s += "\n"
+
+	s += "   [Space] Play/Pause  [n/p] Next/Prev  [s] Shuffle  [r] Repeat  [q] Quit\n"
+	s += "   [←/→] Seek ±5s  [Shift+←/→] ±30s  [+/-] Speed  [[/]] A/B Loop\n\n"
 
 	return s
 }
@@ -217,32 +417,40 @@ func main() {
 
 	switch command {
 	case "record":
-		if len(os.Args) < 3 {
-			fmt.Println("错误: 请指定录音保存路径")
-			fmt.Println("用法: gowave record <output.wav>")
-			os.Exit(1)
-		}
-		runRecord(os.Args[2])
+		outputName, opts, audioOpts := parseRecordArgs(os.Args[2:])
+		runRecord(outputName, opts, audioOpts)
 
 	case "play":
 		if len(os.Args) < 3 {
 			fmt.Println("错误: 请指定要播放的文件")
-			fmt.Println("用法: gowave play <input.wav>")
+			fmt.Println("用法: gowave play [--backend=<name>] [--output-device=<name>] <file|url|dir|playlist> ...")
 			os.Exit(1)
 		}
-		runPlayer(os.Args[2])
+		paths, opts := parsePlayArgs(os.Args[2:])
+		runPlayer(paths, opts)
+
+	case "devices":
+		runDevices(os.Args[2:])
 
 	case "-h", "--help", "help":
 		printUsage()
 
 	default:
 		// 兼容旧用法: gowave <filename> 默认为播放
-		runPlayer(command)
+		runPlayer(os.Args[1:], playerOptions{})
 	}
 }
 
-func runPlayer(filename string) {
-	m, err := initialModel(filename)
+// runPlayer 接受一组文件、目录或播放列表路径，展开成队列后播放。
+// 多个参数、目录递归展开的曲目，以及 .m3u/.m3u8/.pls 里列出的曲目都会被拼接到同一个队列里。
+func runPlayer(paths []string, opts playerOptions) {
+	queue, err := playlist.NewQueue(paths)
+	if err != nil {
+		fmt.Printf("Error building queue: %v\n", err)
+		os.Exit(1)
+	}
+
+	m, err := initialModel(queue, opts)
 	if err != nil {
 		fmt.Printf("Error initializing: %v\n", err)
 		os.Exit(1)
@@ -258,13 +466,116 @@ func runPlayer(filename string) {
 	m.Close()
 }
 
+// parsePlayArgs 解析 `gowave play` 的参数：--backend 选择 audioio 后端
+// （默认 malgo），--output-device 选择该后端下的具体输出设备；
+// 其余位置参数是文件/URL/目录/播放列表路径
+func parsePlayArgs(args []string) (paths []string, opts playerOptions) {
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	backend := fs.String("backend", "", "音频输出后端: malgo(默认)/portaudio/file/null")
+	outputDevice := fs.String("output-device", "", "输出设备名 (file 后端下表示输出文件路径)")
+	fs.Usage = func() {
+		fmt.Println("用法: gowave play [--backend=<name>] [--output-device=<name>] <file|url|dir|playlist> ...")
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if fs.NArg() < 1 {
+		fmt.Println("错误: 请指定要播放的文件")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	return fs.Args(), playerOptions{backend: *backend, outputDevice: *outputDevice}
+}
+
+// runDevices 列出指定后端（默认 malgo）下可用的输入/输出设备
+func runDevices(args []string) {
+	fs := flag.NewFlagSet("devices", flag.ExitOnError)
+	backendName := fs.String("backend", "", "要枚举哪个后端的设备: malgo(默认)/portaudio/file/null")
+	fs.Usage = func() {
+		fmt.Println("用法: gowave devices [--backend=<name>]")
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	backend, err := audioio.Get(*backendName)
+	if err != nil {
+		fmt.Println("错误:", err)
+		os.Exit(1)
+	}
+
+	playback, err := backend.PlaybackDevices()
+	if err != nil {
+		fmt.Println("枚举输出设备失败:", err)
+	}
+	fmt.Printf("后端 %s 的输出设备:\n", backend.Name())
+	for _, d := range playback {
+		fmt.Printf("  - %s\n", d.Name)
+	}
+
+	capture, err := backend.CaptureDevices()
+	if err != nil {
+		fmt.Println("枚举输入设备失败:", err)
+	}
+	fmt.Printf("后端 %s 的输入设备:\n", backend.Name())
+	for _, d := range capture {
+		fmt.Printf("  - %s\n", d.Name)
+	}
+}
+
+// parseRecordArgs 解析 `gowave record` 的参数：除了输出路径，
+// 还支持 --trim-silence / --vad-only / --auto-stop=<seconds> 三个 VAD 相关开关，
+// 以及 --backend / --input-device 两个音频后端相关的开关
+func parseRecordArgs(args []string) (outputName string, opts recordOptions, audioOpts recordAudioOptions) {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	trimSilence := fs.Bool("trim-silence", false, "丢弃录音首尾的非语音片段")
+	vadOnly := fs.Bool("vad-only", false, "只保留语音片段，片段间做短淡入淡出")
+	autoStop := fs.Float64("auto-stop", 0, "连续静音超过这个秒数后自动停止录音 (0 表示关闭)")
+	backend := fs.String("backend", "", "音频输入后端: malgo(默认)/portaudio/null")
+	inputDevice := fs.String("input-device", "", "输入设备名，留空使用默认设备")
+	fs.Usage = func() {
+		fmt.Println("用法: gowave record [--trim-silence] [--vad-only] [--auto-stop=<seconds>]")
+		fmt.Println("                    [--backend=<name>] [--input-device=<name>] <output.wav>")
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if fs.NArg() < 1 {
+		fmt.Println("错误: 请指定录音保存路径")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	opts = recordOptions{trimSilence: *trimSilence, vadOnly: *vadOnly}
+	if *autoStop > 0 {
+		opts.autoStopSilence = time.Duration(*autoStop * float64(time.Second))
+	}
+	audioOpts = recordAudioOptions{backend: *backend, inputDevice: *inputDevice}
+	return fs.Arg(0), opts, audioOpts
+}
+
 func printUsage() {
 	fmt.Println("Gowave - 一个简单的命令行音频播放与录音工具")
 	fmt.Println("\n用法:")
-	fmt.Println("  gowave play <file.wav>    播放 WAV 文件")
-	fmt.Println("  gowave record <file.wav>  录制音频到 WAV 文件")
-	fmt.Println("  gowave <file.wav>         播放 WAV 文件 (简写)")
+	fmt.Println("  gowave play [--backend=<name>] [--output-device=<name>]     播放一个或多个文件/目录/.m3u|.pls 播放列表")
+	fmt.Println("              <file|url|dir|playlist> ...")
+	fmt.Println("  gowave record [--trim-silence] [--vad-only]                 录制音频到 WAV 文件")
+	fmt.Println("                [--auto-stop=<seconds>] [--backend=<name>]")
+	fmt.Println("                [--input-device=<name>] <output.wav>")
+	fmt.Println("  gowave devices [--backend=<name>]                           列出某个后端的输入/输出设备")
+	fmt.Println("  gowave <file|url> ...                                       播放音频 (简写)")
+	fmt.Println("\n可用后端 (--backend):  malgo (默认) / portaudio / file / null")
 	fmt.Println("\n快捷键 (播放模式):")
 	fmt.Println("  [Space]  暂停/播放")
+	fmt.Println("  [n/p]    下一首/上一首")
+	fmt.Println("  [s]      切换乱序播放")
+	fmt.Println("  [r]      切换循环模式 (off/single/all)")
+	fmt.Println("  [←/→]    快退/快进 5s (Shift 为 30s)")
+	fmt.Println("  [+/-]    加速/减速 0.1x (变速不变调，0.5x~2.0x)")
+	fmt.Println("  [[/]]    设置 A/B 循环点")
 	fmt.Println("  [q/Esc]  退出")
+	fmt.Println("\n录音模式下按 [q] 停止并保存")
 }
@@ -0,0 +1,247 @@
+// Package playlist 实现播放队列：把命令行传入的文件/目录/播放列表
+// 展开成一组音轨路径，并提供上一首/下一首/乱序/循环等导航。
+package playlist
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RepeatMode 描述队列放完后的行为
+type RepeatMode int
+
+const (
+	RepeatOff RepeatMode = iota // 放完整个队列后停止
+	RepeatOne                   // 单曲循环
+	RepeatAll                   // 整个队列循环
+)
+
+func (m RepeatMode) String() string {
+	switch m {
+	case RepeatOne:
+		return "single"
+	case RepeatAll:
+		return "all"
+	default:
+		return "off"
+	}
+}
+
+var audioExts = map[string]bool{
+	".wav": true, ".mp3": true, ".flac": true, ".ogg": true, ".oga": true,
+}
+
+// Queue 保存展开后的音轨列表，以及当前的播放顺序和模式
+type Queue struct {
+	tracks  []string
+	order   []int // tracks 的播放顺序（乱序时被打乱）
+	pos     int   // order 中的当前下标
+	shuffle bool
+	repeat  RepeatMode
+}
+
+// NewQueue 展开命令行传入的每一个路径（文件/目录/.m3u|.m3u8|.pls 播放列表）
+// 拼成一份扁平的音轨列表
+func NewQueue(paths []string) (*Queue, error) {
+	var tracks []string
+	for _, p := range paths {
+		expanded, err := expand(p)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, expanded...)
+	}
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("playlist: 没有找到可播放的音轨")
+	}
+
+	q := &Queue{tracks: tracks}
+	q.resetOrder()
+	return q, nil
+}
+
+func expand(path string) ([]string, error) {
+	// http(s):// 音频流直接作为单条音轨，不做本地文件展开
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return []string{path}, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 %s 失败: %w", path, err)
+	}
+	if info.IsDir() {
+		return walkDir(path)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m3u", ".m3u8":
+		return parseM3U(path)
+	case ".pls":
+		return parsePLS(path)
+	default:
+		return []string{path}, nil
+	}
+}
+
+// walkDir 递归遍历目录，收集所有已知扩展名的音频文件，按路径排序
+func walkDir(dir string) ([]string, error) {
+	var out []string
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		if audioExts[strings.ToLower(filepath.Ext(p))] {
+			out = append(out, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// parseM3U 解析 .m3u/.m3u8：忽略空行和 # 开头的注释/扩展标签，
+// 其余每一行是一条相对或绝对路径
+func parseM3U(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开播放列表 %s 失败: %w", path, err)
+	}
+	defer f.Close()
+
+	base := filepath.Dir(path)
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, resolve(base, line))
+	}
+	return out, sc.Err()
+}
+
+// parsePLS 解析 .pls：只关心形如 FileN=... 的条目
+func parsePLS(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开播放列表 %s 失败: %w", path, err)
+	}
+	defer f.Close()
+
+	base := filepath.Dir(path)
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if !strings.HasPrefix(line, "File") {
+			continue
+		}
+		if idx := strings.Index(line, "="); idx >= 0 {
+			out = append(out, resolve(base, line[idx+1:]))
+		}
+	}
+	return out, sc.Err()
+}
+
+func resolve(base, p string) string {
+	if strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://") || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(base, p)
+}
+
+// --- 导航 ---
+
+func (q *Queue) resetOrder() {
+	q.order = make([]int, len(q.tracks))
+	for i := range q.order {
+		q.order[i] = i
+	}
+	if q.shuffle {
+		q.shuffleOrder()
+	}
+	q.pos = 0
+}
+
+func (q *Queue) shuffleOrder() {
+	rand.Shuffle(len(q.order), func(i, j int) {
+		q.order[i], q.order[j] = q.order[j], q.order[i]
+	})
+}
+
+// Current 返回当前音轨路径
+func (q *Queue) Current() string {
+	return q.tracks[q.order[q.pos]]
+}
+
+// Next 按 repeat/shuffle 模式前进到下一首，返回新的当前音轨。
+// ok 为 false 表示队列已经放完，调用方应当停止播放。
+func (q *Queue) Next() (path string, ok bool) {
+	if q.repeat == RepeatOne {
+		return q.Current(), true
+	}
+	if q.pos+1 < len(q.order) {
+		q.pos++
+		return q.Current(), true
+	}
+	if q.repeat == RepeatAll {
+		if q.shuffle {
+			q.shuffleOrder()
+		}
+		q.pos = 0
+		return q.Current(), true
+	}
+	return "", false
+}
+
+// Prev 回退到上一首（到头后停在第一首），返回新的当前音轨
+func (q *Queue) Prev() string {
+	if q.pos > 0 {
+		q.pos--
+	}
+	return q.Current()
+}
+
+// ToggleShuffle 打开/关闭乱序播放；打开时立即重新打乱剩余队列
+func (q *Queue) ToggleShuffle() bool {
+	q.shuffle = !q.shuffle
+	if q.shuffle {
+		rest := q.order[q.pos+1:]
+		rand.Shuffle(len(rest), func(i, j int) {
+			rest[i], rest[j] = rest[j], rest[i]
+		})
+	}
+	return q.shuffle
+}
+
+func (q *Queue) Shuffle() bool { return q.shuffle }
+
+// CycleRepeat 依次在 off -> one -> all -> off 间切换
+func (q *Queue) CycleRepeat() RepeatMode {
+	q.repeat = (q.repeat + 1) % 3
+	return q.repeat
+}
+
+func (q *Queue) Repeat() RepeatMode { return q.repeat }
+
+// Upcoming 返回队列中接下来最多 n 首的路径，用于在 UI 中预览
+func (q *Queue) Upcoming(n int) []string {
+	var out []string
+	for i := q.pos + 1; i < len(q.order) && len(out) < n; i++ {
+		out = append(out, q.tracks[q.order[i]])
+	}
+	return out
+}
@@ -1,133 +1,154 @@
 package main
 
 import (
-	"encoding/binary"
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
-
-	"github.com/gen2brain/malgo"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/go-audio/audio"
 	"github.com/go-audio/wav"
+
+	"github.com/adfoke/gowave/audioio"
 )
 
 // 配置参数
 const (
-	SampleRate  = 44100
-	Channels    = 1
-	BitDepth    = 16
-	OutputName  = "output.wav"
+	SampleRate = 44100
+	Channels   = 1
+	BitDepth   = 16
+	OutputName = "output.wav"
 )
 
-func runRecord(outputName string) {
-	// 1. 初始化 Malgo 上下文
-	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+// recordAudioOptions 是 `gowave record` 里跟音频后端相关的命令行选项
+type recordAudioOptions struct {
+	backend     string // audioio 后端名字："malgo"（默认）、"portaudio"、"null"
+	inputDevice string // 留空表示使用该后端的默认输入设备
+}
+
+func runRecord(outputName string, opts recordOptions, audioOpts recordAudioOptions) {
+	backend, err := audioio.Get(audioOpts.backend)
 	if err != nil {
 		panic(err)
 	}
-	defer func() {
-		_ = ctx.Uninit()
-		ctx.Free()
-	}()
-
-	// 2. 创建一个 Channel 用于传输音频数据
-	// 缓冲区设置大一点，防止写入文件太慢导致数据丢失
-	audioChan := make(chan []byte, 1024)
-
-	// 3. 配置麦克风参数
-	deviceConfig := malgo.DefaultDeviceConfig(malgo.Capture)
-	deviceConfig.Capture.Format = malgo.FormatS16 // 16位深度
-	deviceConfig.Capture.Channels = Channels
-	deviceConfig.SampleRate = SampleRate
-	deviceConfig.Alsa.NoMMap = 1 // Linux 特有修正，防止某些驱动报错
-
-	// 4. 定义回调函数：当麦克风有声音进来时调用
-	deviceCallbacks := malgo.DeviceCallbacks{
-		Data: func(pOutputSample, pInputSamples []byte, framecount uint32) {
-			// 注意：这里必须拷贝数据，因为 pInputSamples 在函数结束后会被底层复用
-			dataCopy := make([]byte, len(pInputSamples))
-			copy(dataCopy, pInputSamples)
-			
-			// 将数据发送到管道，如果在处理不过来则丢弃（非阻塞），防止卡死硬件
-			select {
-			case audioChan <- dataCopy:
-			default:
-				// Channel 满了，丢弃这一帧（通常不会发生，除非磁盘太慢）
-			}
-		},
-	}
 
-	// 5. 初始化设备
-	device, err := malgo.InitDevice(ctx.Context, deviceConfig, deviceCallbacks)
+	// 1. 打开音频输入（麦克风，或 null 后端下的静音源）
+	src, err := backend.OpenSource(audioio.Format{SampleRate: SampleRate, Channels: Channels}, audioOpts.inputDevice)
 	if err != nil {
 		panic(err)
 	}
 
-	// 6. 准备 WAV 文件写入
+	// 2. 准备 WAV 文件写入
 	outFile, err := os.Create(outputName)
 	if err != nil {
 		panic(err)
 	}
-	defer outFile.Close()
-
-	// 创建 WAV 编码器
-	// 参数: writer, sampleRate, bitDepth, numChans, audioFormat(1=PCM)
 	encoder := wav.NewEncoder(outFile, SampleRate, BitDepth, Channels, 1)
 
-	// 7. 启动录音设备
-	if err := device.Start(); err != nil {
-		panic(err)
-	}
+	// 3. VAD 检测器和片段裁剪/静音门限状态
+	vad := newVADDetector(SampleRate)
+	segments := newSegmentWriter(opts)
+	stats := &recordStats{}
 
-	fmt.Printf("正在录音... 请说话 (按 Ctrl+C 停止并保存)\n")
+	// 4. stop 用于让 UI 侧的 [q] 或 SIGTERM 通知采集协程提前结束（关闭 src 会让
+	// 阻塞中的 Read 返回错误）；sync.Once 避免自动停止和手动停止同时触发时重复关闭
+	var stopOnce sync.Once
+	stop := func() { stopOnce.Do(func() { _ = src.Close() }) }
 
-	// 8. 启动一个协程处理 Ctrl+C 信号，确保文件正确关闭
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	// 9. 主循环：从 Channel 读取数据并编码写入文件
-	// 这里使用 label 跳出循环
-Loop:
-	for {
-		select {
-		case <-sigChan:
-			fmt.Println("\n检测到停止信号，正在保存文件...")
-			break Loop // 跳出 for 循环
-
-		case data := <-audioChan:
-			// malgo 给的是 []byte，wav 库需要 IntBuffer
-			// 我们需要把 []byte (Little Endian) 转成 int
-			intData := make([]int, len(data)/2)
-			for i := 0; i < len(intData); i++ {
-				// 将两个 byte 转成一个 int16，再转成 int
-				val := int16(binary.LittleEndian.Uint16(data[i*2 : i*2+2]))
-				intData[i] = int(val)
-			}
+	signal.Notify(sigChan, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		stop()
+	}()
 
-			// 写入 WAV 编码器
-			buf := &audio.IntBuffer{
-				Format: &audio.Format{
-					SampleRate:  SampleRate,
-					NumChannels: Channels,
-				},
-				Data:           intData,
-				SourceBitDepth: BitDepth,
+	done := make(chan struct{})
+
+	// 5. 采集协程：从 audioio.Source 读取样本，按 20ms 一帧切给 VAD 判决，
+	// 再按需裁剪/丢弃后写入编码器
+	go func() {
+		defer close(done)
+
+		frameSamples := vad.frameSamples
+		var pending []float32
+		buf := make([]float32, 4096)
+		start := time.Now()
+
+	Loop:
+		for {
+			n, err := src.Read(buf)
+			if err != nil {
+				break Loop // src 被 stop() 关闭，或者后端出错
 			}
-			if err := encoder.Write(buf); err != nil {
-				fmt.Println("写入错误:", err)
+			pending = append(pending, buf[:n]...)
+
+			for len(pending) >= frameSamples {
+				frame := float32ToInt16(pending[:frameSamples])
+				pending = pending[frameSamples:]
+
+				isSpeech, dbfs := vad.classify(frame)
+				if err := segments.process(encoder, frame, isSpeech); err != nil {
+					fmt.Println("写入错误:", err)
+				}
+
+				stats.update(dbfs, isSpeech, time.Since(start), opts.autoStopSilence)
+				if _, _, _, autoStopped := stats.snapshot(); autoStopped {
+					break Loop
+				}
 			}
 		}
-	}
 
-	// 10. 收尾工作
-	device.Uninit()
-	
-	//以此关闭编码器至关重要！它会回过头去修改文件头的“文件大小”字段
-	if err := encoder.Close(); err != nil {
-		fmt.Println("关闭 WAV 编码器失败:", err)
+		stop()
+
+		// 以此关闭编码器至关重要！它会回过头去修改文件头的"文件大小"字段
+		if err := encoder.Close(); err != nil {
+			fmt.Println("关闭 WAV 编码器失败:", err)
+		}
+		outFile.Close()
+	}()
+
+	// 6. 启动一个小型 Bubble Tea UI，实时展示电平和已录制时长，风格与播放器一致
+	p := tea.NewProgram(newRecordModel(stats, stop))
+	if _, err := p.Run(); err != nil {
+		fmt.Printf("Alas, there's been an error: %v", err)
 	}
-	
+	stop() // UI 正常退出 (比如因为自动停止) 时，确保采集协程也会收到停止信号
+
+	<-done
 	fmt.Println("录音完成！已保存为", outputName)
 }
+
+// float32ToInt16 把 audioio 统一使用的 [-1,1] 浮点样本转换成 16 位 PCM 样本
+func float32ToInt16(samples []float32) []int16 {
+	out := make([]int16, len(samples))
+	for i, v := range samples {
+		if v > 1 {
+			v = 1
+		}
+		if v < -1 {
+			v = -1
+		}
+		out[i] = int16(v * 32767)
+	}
+	return out
+}
+
+// writeFrame 把一帧 int16 样本写入 WAV 编码器
+func writeFrame(enc *wav.Encoder, samples []int16) error {
+	intData := make([]int, len(samples))
+	for i, s := range samples {
+		intData[i] = int(s)
+	}
+	buf := &audio.IntBuffer{
+		Format: &audio.Format{
+			SampleRate:  SampleRate,
+			NumChannels: Channels,
+		},
+		Data:           intData,
+		SourceBitDepth: BitDepth,
+	}
+	return enc.Write(buf)
+}
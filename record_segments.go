@@ -0,0 +1,93 @@
+package main
+
+import (
+	"time"
+
+	"github.com/go-audio/wav"
+)
+
+// recordOptions 是 `gowave record` 的 VAD 相关命令行选项
+type recordOptions struct {
+	trimSilence     bool          // 丢弃首尾的非语音帧
+	vadOnly         bool          // 只保留语音片段，片段间做短淡入淡出
+	autoStopSilence time.Duration // 连续静音超过这个时长就自动停止录音；0 表示不启用
+}
+
+// segmentWriter 根据 recordOptions 决定每一帧该不该写入编码器，
+// 并在 vad-only 模式下为语音片段的首尾加上短淡入/淡出，避免咔嗒声。
+type segmentWriter struct {
+	opts recordOptions
+
+	started         bool      // trim-silence: 是否已经出现过语音，越过了前导静音
+	inSpeechSegment bool      // vad-only: 当前是否处于一段语音中
+	pendingSilence  [][]int16 // trim-silence: 缓存可能是内部静音或尾部静音的帧
+}
+
+func newSegmentWriter(opts recordOptions) *segmentWriter {
+	return &segmentWriter{opts: opts}
+}
+
+// process 决定如何处理这一帧，必要时写入 enc
+func (w *segmentWriter) process(enc *wav.Encoder, frame []int16, isSpeech bool) error {
+	switch {
+	case w.opts.vadOnly:
+		return w.processVADOnly(enc, frame, isSpeech)
+	case w.opts.trimSilence:
+		return w.processTrimSilence(enc, frame, isSpeech)
+	default:
+		return writeFrame(enc, frame)
+	}
+}
+
+func (w *segmentWriter) processVADOnly(enc *wav.Encoder, frame []int16, isSpeech bool) error {
+	if isSpeech {
+		out := frame
+		if !w.inSpeechSegment {
+			out = applyFade(frame, true) // 片段开始：淡入
+		}
+		w.inSpeechSegment = true
+		return writeFrame(enc, out)
+	}
+
+	if w.inSpeechSegment {
+		// 刚从语音转为静音：把这一帧做成淡出收尾，之后的纯静音帧整帧丢弃
+		w.inSpeechSegment = false
+		return writeFrame(enc, applyFade(frame, false))
+	}
+	return nil
+}
+
+func (w *segmentWriter) processTrimSilence(enc *wav.Encoder, frame []int16, isSpeech bool) error {
+	if isSpeech {
+		w.started = true
+		for _, pending := range w.pendingSilence {
+			if err := writeFrame(enc, pending); err != nil {
+				return err
+			}
+		}
+		w.pendingSilence = w.pendingSilence[:0]
+		return writeFrame(enc, frame)
+	}
+
+	if !w.started {
+		return nil // 还没出现过语音，属于前导静音，直接丢弃
+	}
+	// 暂时无法判断这段静音是内部的还是录音结尾的尾部静音，先缓存；
+	// 录音正常结束时这些缓存永远不会被 flush，相当于被裁掉了
+	w.pendingSilence = append(w.pendingSilence, frame)
+	return nil
+}
+
+// applyFade 对一帧样本做线性淡入/淡出包络
+func applyFade(samples []int16, fadeIn bool) []int16 {
+	n := len(samples)
+	out := make([]int16, n)
+	for i, s := range samples {
+		gain := float64(n-i) / float64(n)
+		if fadeIn {
+			gain = float64(i) / float64(n)
+		}
+		out[i] = int16(float64(s) * gain)
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// recordStats 是录音 goroutine 和 UI goroutine之间共享的状态，由 mutex 保护
+type recordStats struct {
+	mu            sync.Mutex
+	dbfs          float64
+	isSpeech      bool
+	elapsed       time.Duration
+	silenceStreak time.Duration
+	autoStopped   bool
+}
+
+func (s *recordStats) snapshot() (dbfs float64, isSpeech bool, elapsed time.Duration, autoStopped bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dbfs, s.isSpeech, s.elapsed, s.autoStopped
+}
+
+func (s *recordStats) update(dbfs float64, isSpeech bool, elapsed time.Duration, autoStopSilence time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dbfs = dbfs
+	s.isSpeech = isSpeech
+	s.elapsed = elapsed
+	if isSpeech {
+		s.silenceStreak = 0
+	} else {
+		s.silenceStreak += vadFrameMs * time.Millisecond
+	}
+	if autoStopSilence > 0 && s.silenceStreak >= autoStopSilence {
+		s.autoStopped = true
+	}
+}
+
+// recordModel 是录音时展示的小型 Bubble Tea UI：一条电平表和已录制时长，
+// 风格与播放器的进度/电平展示保持一致
+type recordModel struct {
+	stats   *recordStats
+	stop    func()
+	dbfs    float64
+	speech  bool
+	elapsed time.Duration
+}
+
+func newRecordModel(stats *recordStats, stop func()) recordModel {
+	return recordModel{stats: stats, stop: stop}
+}
+
+func (m recordModel) Init() tea.Cmd {
+	return tickCmd()
+}
+
+func (m recordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			m.stop()
+			return m, tea.Quit
+		}
+
+	case tickMsg:
+		dbfs, speech, elapsed, autoStopped := m.stats.snapshot()
+		m.dbfs = dbfs
+		m.speech = speech
+		m.elapsed = elapsed
+		if autoStopped {
+			return m, tea.Quit
+		}
+		return m, tickCmd()
+	}
+	return m, nil
+}
+
+func (m recordModel) View() string {
+	status := "🔇 Silence"
+	if m.speech {
+		status = "🎙 Speech "
+	}
+
+	levelFrac := linearToDBFrac(math.Pow(10, m.dbfs/20))
+
+	s := "\n"
+	s += fmt.Sprintf("⏺ Recording...  %s\n\n", status)
+	s += "   " + renderMeter("Lvl ", levelFrac, 0, 40) + "\n\n"
+	s += fmt.Sprintf("   ⏱  %v\n\n", m.elapsed.Round(time.Second))
+	s += "   [q] Stop & Save\n\n"
+	return s
+}
@@ -0,0 +1,74 @@
+package main
+
+import "math"
+
+// 语音活动检测 (VAD) 相关参数
+const (
+	vadFrameMs        = 20  // 每帧时长，录音的最小判决粒度
+	vadWarmupMs       = 500 // 用前 500ms 估计初始噪声基底
+	vadSpeechMarginDB = 6.0 // RMS 超过噪声基底多少 dB 才算语音
+	vadZCRMin         = 10  // 语音帧的过零率下限 (次/帧)
+	vadZCRMax         = 100 // 语音帧的过零率上限 (次/帧)
+)
+
+// vadDetector 是一个简单的能量 + 过零率语音活动检测器：用自适应噪声基底
+// （开局 500ms 初始化，之后在非语音帧上做指数滑动平均）判断每一帧是否为语音
+type vadDetector struct {
+	frameSamples int
+	warmupFrames int
+	seenFrames   int
+	noiseFloor   float64 // 线性 RMS (0~1)，自适应噪声基底
+}
+
+func newVADDetector(sampleRate int) *vadDetector {
+	frameSamples := sampleRate * vadFrameMs / 1000
+	warmupFrames := sampleRate * vadWarmupMs / 1000 / frameSamples
+	if warmupFrames < 1 {
+		warmupFrames = 1
+	}
+	return &vadDetector{frameSamples: frameSamples, warmupFrames: warmupFrames}
+}
+
+// classify 对一帧 int16 PCM 样本做判决，返回是否为语音帧，以及该帧的 dBFS 电平
+// （dBFS 同时也用于 UI 的实时电平表）
+func (v *vadDetector) classify(frame []int16) (isSpeech bool, dbfs float64) {
+	rms := rmsInt16(frame) / 32768
+	dbfs = 20 * math.Log10(rms+1e-9)
+
+	if v.seenFrames < v.warmupFrames {
+		// 预热阶段：假设环境还是安静的，用于初始化噪声基底
+		v.seenFrames++
+		v.noiseFloor += (rms - v.noiseFloor) / float64(v.seenFrames)
+		return false, dbfs
+	}
+
+	noiseFloorDB := 20 * math.Log10(v.noiseFloor+1e-9)
+	zcr := zeroCrossings(frame)
+	isSpeech = dbfs > noiseFloorDB+vadSpeechMarginDB && zcr >= vadZCRMin && zcr <= vadZCRMax
+
+	if !isSpeech {
+		v.noiseFloor = 0.95*v.noiseFloor + 0.05*rms
+	}
+	return isSpeech, dbfs
+}
+
+func rmsInt16(frame []int16) float64 {
+	if len(frame) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range frame {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(frame)))
+}
+
+func zeroCrossings(frame []int16) int {
+	count := 0
+	for i := 1; i < len(frame); i++ {
+		if (frame[i-1] >= 0) != (frame[i] >= 0) {
+			count++
+		}
+	}
+	return count
+}
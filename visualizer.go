@@ -0,0 +1,206 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/faiface/beep"
+)
+
+// 频谱分析相关参数
+const (
+	fftSize       = 1024 // 参与 FFT 的样本数，必须是 2 的幂
+	spectrumBars  = 40   // 频谱柱子的数量
+	dbFloor       = -60.0
+	dbCeil        = 0.0
+	peakHoldDecay = 1.0 // 峰值保持每 100ms 衰减 1 dB
+)
+
+// 将 dB 幅值映射到方块字符的 8 档精细度
+var blockGlyphs = []rune("▁▂▃▄▅▆▇█")
+
+// sampleRing 是一个定长环形缓冲区，保存最近播放的单声道样本
+// 由 tapStreamer 在 engine 的播放协程中写入，由 UI 协程在每个 tick 读取
+type sampleRing struct {
+	mu   sync.Mutex
+	buf  []float64
+	pos  int
+	full bool
+}
+
+func newSampleRing(size int) *sampleRing {
+	return &sampleRing{buf: make([]float64, size)}
+}
+
+// write 追加样本，环满后从头覆盖
+func (r *sampleRing) write(samples []float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range samples {
+		r.buf[r.pos] = s
+		r.pos = (r.pos + 1) % len(r.buf)
+		if r.pos == 0 {
+			r.full = true
+		}
+	}
+}
+
+// latest 返回按时间顺序排列的最近 n 个样本（不足时返回已有的部分）
+func (r *sampleRing) latest(n int) []float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	available := r.pos
+	if r.full {
+		available = len(r.buf)
+	}
+	if n > available {
+		n = available
+	}
+	if n == 0 {
+		return nil
+	}
+
+	out := make([]float64, n)
+	start := r.pos - n
+	for i := 0; i < n; i++ {
+		idx := (start + i + len(r.buf)) % len(r.buf)
+		out[i] = r.buf[idx]
+	}
+	return out
+}
+
+// tapStreamer 包装一个 beep.Streamer，在不影响播放的前提下把每次
+// Stream() 产出的缓冲区（左右声道取平均）镜像写入 ring 供可视化使用
+type tapStreamer struct {
+	beep.Streamer
+	ring *sampleRing
+}
+
+func newTapStreamer(src beep.Streamer, ring *sampleRing) *tapStreamer {
+	return &tapStreamer{Streamer: src, ring: ring}
+}
+
+func (t *tapStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = t.Streamer.Stream(samples)
+	if n > 0 {
+		mono := make([]float64, n)
+		for i := 0; i < n; i++ {
+			mono[i] = (samples[i][0] + samples[i][1]) / 2
+		}
+		t.ring.write(mono)
+	}
+	return n, ok
+}
+
+// spectrumState 保存 View() 渲染频谱/VU 表所需的全部派生数据
+type spectrumState struct {
+	bars     []float64 // 每根柱子的 dB 值，已裁剪到 [dbFloor, dbCeil]
+	rms      float64   // 当前 RMS，线性 0~1
+	peak     float64   // 当前峰值，线性 0~1
+	peakHold float64   // 带衰减的峰值保持，用于 VU 表的 "高水位线"
+}
+
+// update 从 ring 中取出最近 fftSize 个样本，计算频谱柱和 VU 电平
+func (s *spectrumState) update(ring *sampleRing) {
+	samples := ring.latest(fftSize)
+	if len(samples) < fftSize {
+		// 数据还不够一个完整窗口，补零避免 FFT 长度不对
+		padded := make([]float64, fftSize)
+		copy(padded[fftSize-len(samples):], samples)
+		samples = padded
+	}
+
+	magsDB := magnitudesDB(samples)
+	raw := logBin(magsDB, spectrumBars)
+	bars := make([]float64, spectrumBars)
+	for i, db := range raw {
+		bars[i] = clamp(db, dbFloor, dbCeil)
+	}
+	s.bars = bars
+
+	rms, peak := rmsAndPeak(samples)
+	s.rms = rms
+	s.peak = peak
+
+	if peak >= s.peakHold {
+		s.peakHold = peak
+	} else {
+		// 峰值保持按每个 tick (100ms) 衰减 peakHoldDecay dB
+		holdDB := 20*math.Log10(s.peakHold+1e-12) - peakHoldDecay
+		s.peakHold = math.Max(0, math.Pow(10, holdDB/20))
+	}
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+var spectrumGradient = lipgloss.NewStyle() // 基础样式，颜色按柱高动态着色
+
+// barColor 按柱子高度在绿-黄-红之间做渐变，越高越接近削波越红
+func barColor(frac float64) lipgloss.Color {
+	switch {
+	case frac > 0.85:
+		return lipgloss.Color("196") // 红
+	case frac > 0.6:
+		return lipgloss.Color("220") // 黄
+	default:
+		return lipgloss.Color("42") // 绿
+	}
+}
+
+// renderSpectrum 把 dB 柱值渲染成一行彩色方块字符
+func renderSpectrum(bars []float64) string {
+	var b strings.Builder
+	for _, db := range bars {
+		frac := (db - dbFloor) / (dbCeil - dbFloor)
+		idx := int(frac * float64(len(blockGlyphs)-1))
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= len(blockGlyphs) {
+			idx = len(blockGlyphs) - 1
+		}
+		glyph := string(blockGlyphs[idx])
+		b.WriteString(spectrumGradient.Foreground(barColor(frac)).Render(glyph))
+	}
+	return b.String()
+}
+
+// renderMeter 渲染一条宽度为 width 的电平条，levelFrac 为 0~1 的填充比例，
+// markFrac（若 >0）在对应位置画一个峰值保持标记 "|"
+func renderMeter(label string, levelFrac, markFrac float64, width int) string {
+	filled := int(clamp(levelFrac, 0, 1) * float64(width))
+	runes := make([]rune, width)
+	for i := range runes {
+		runes[i] = ' '
+	}
+	for i := 0; i < filled; i++ {
+		runes[i] = '█'
+	}
+	if markFrac > 0 {
+		mi := int(clamp(markFrac, 0, 1) * float64(width))
+		if mi >= width {
+			mi = width - 1
+		}
+		runes[mi] = '|'
+	}
+	bar := spectrumGradient.Foreground(barColor(levelFrac)).Render(string(runes))
+	return label + " [" + bar + "]"
+}
+
+// linearToDBFrac 把线性幅度（0~1）换算成 [dbFloor, dbCeil] 区间内的归一化刻度，
+// 供 VU 表与频谱柱共用同一套 dB 量程
+func linearToDBFrac(linear float64) float64 {
+	db := 20 * math.Log10(linear+1e-12)
+	return (clamp(db, dbFloor, dbCeil) - dbFloor) / (dbCeil - dbFloor)
+}